@@ -6,7 +6,8 @@ use std::path::Path;
 /// Atomically write `bytes` to `path`.
 ///
 /// Writes to a temporary file, syncs, renames over `path` and then fsyncs the
-/// parent directory to ensure durability.
+/// parent directory to ensure durability. If `path` already exists its
+/// permissions are carried over to the replacement file.
 pub fn atomic_write(path: &Path, bytes: &[u8]) -> io::Result<()> {
     let dir = path
         .parent()
@@ -15,10 +16,14 @@ pub fn atomic_write(path: &Path, bytes: &[u8]) -> io::Result<()> {
     let name = path
         .file_name()
         .ok_or_else(|| io::Error::other("missing file name"))?;
+    let perms = fs::metadata(path).ok().map(|m| m.permissions());
     let nonce: u64 = rand::thread_rng().r#gen();
     tmp.push(format!(".{}.gw.tmp.{}", name.to_string_lossy(), nonce));
     let mut f = OpenOptions::new().create_new(true).write(true).open(&tmp)?;
     f.write_all(bytes)?;
+    if let Some(perms) = perms {
+        f.set_permissions(perms)?;
+    }
     f.sync_all()?;
     fs::rename(&tmp, path)?;
     let dirf = File::open(dir)?;
@@ -53,6 +58,20 @@ mod tests {
         assert_eq!(entries.len(), 1);
     }
 
+    #[cfg(unix)]
+    #[test]
+    fn atomic_write_preserves_permissions() {
+        use std::os::unix::fs::PermissionsExt;
+
+        let dir = tempdir().unwrap();
+        let path = dir.path().join("script.sh");
+        fs::write(&path, b"old").unwrap();
+        fs::set_permissions(&path, fs::Permissions::from_mode(0o750)).unwrap();
+        atomic_write(&path, b"new").unwrap();
+        let mode = fs::metadata(&path).unwrap().permissions().mode();
+        assert_eq!(mode & 0o777, 0o750);
+    }
+
     #[test]
     fn atomic_write_missing_parent_errors() {
         let path = std::path::Path::new("");